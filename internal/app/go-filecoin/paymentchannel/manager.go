@@ -3,6 +3,7 @@ package paymentchannel
 import (
 	"bytes"
 	"context"
+	"sync"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-fil-markets/shared"
@@ -20,6 +21,7 @@ import (
 	"github.com/prometheus/common/log"
 
 	"github.com/filecoin-project/go-filecoin/internal/pkg/block"
+	"github.com/filecoin-project/go-filecoin/internal/pkg/crypto"
 	"github.com/filecoin-project/go-filecoin/internal/pkg/encoding"
 	"github.com/filecoin-project/go-filecoin/internal/pkg/types"
 	"github.com/filecoin-project/go-filecoin/internal/pkg/vm"
@@ -35,9 +37,37 @@ var zeroAmt = abi.NewTokenAmount(0)
 type Manager struct {
 	ctx             context.Context
 	paymentChannels *statestore.StateStore
+	pendingMessages *statestore.StateStore
 	sender          MsgSender
 	waiter          MsgWaiter
 	stateViewer     ActorStateViewer
+
+	pendingMu sync.Mutex
+	pending   map[cid.Cid]*pendingWait
+}
+
+// pendingWait lets every caller waiting on the same pending message's cid
+// -- the background goroutine that sent it, a later PaychGetWaitReady call,
+// and Manager.Start's restart recovery -- join a single waiter.Wait instead
+// of each invoking it independently, which would double-apply its effects
+// (reservation bumps, pendingMessages bookkeeping).
+type pendingWait struct {
+	done chan struct{}
+	addr address.Address
+	err  error
+}
+
+// ErrChannelNotReady is returned by PaychGet when opts.OffChain is true and
+// no existing channel has enough available balance to cover the requested
+// amount.
+var ErrChannelNotReady = xerrors.New("payment channel not ready")
+
+// PaychGetOpts controls PaychGet's behavior.
+type PaychGetOpts struct {
+	// OffChain, if true, instructs PaychGet to never send an on-chain
+	// message; it returns ErrChannelNotReady instead of a cid when no
+	// channel exists yet, or its available balance is insufficient.
+	OffChain bool
 }
 
 // PaymentChannelStorePrefix is the prefix used in the datastore
@@ -69,7 +99,41 @@ type ActorStateViewer interface {
 // NewManager creates and returns a new paymentchannel.Manager
 func NewManager(ctx context.Context, ds datastore.Batching, waiter MsgWaiter, sender MsgSender, viewer ActorStateViewer) *Manager {
 	store := statestore.New(namespace.Wrap(ds, datastore.NewKey(PaymentChannelStorePrefix)))
-	return &Manager{ctx, store, sender, waiter, viewer}
+	pendingStore := statestore.New(namespace.Wrap(ds, datastore.NewKey(PaymentChannelStorePrefix+"/pending")))
+	return &Manager{
+		ctx:             ctx,
+		paymentChannels: store,
+		pendingMessages: pendingStore,
+		sender:          sender,
+		waiter:          waiter,
+		stateViewer:     viewer,
+		pending:         make(map[cid.Cid]*pendingWait),
+	}
+}
+
+// Start recovers any in-flight create or add-funds messages that were
+// persisted before a prior process exited without seeing them land, so that
+// restarting the node can't orphan funds sent to a payment channel actor.
+func (pm *Manager) Start(ctx context.Context) error {
+	var pending []PendingMessage
+	if err := pm.pendingMessages.List(&pending); err != nil {
+		return err
+	}
+	for _, msg := range pending {
+		go pm.recoverPending(ctx, msg)
+	}
+	return nil
+}
+
+// recoverPending re-joins the wait for a message recorded in
+// pendingMessages through awaitPending, the same entry point used by a
+// freshly sent message's own background goroutine and by PaychGetWaitReady,
+// so recovery after a restart can't double-apply the message's effects
+// against a goroutine that was already waiting on it.
+func (pm *Manager) recoverPending(ctx context.Context, msg PendingMessage) {
+	if _, err := pm.awaitPending(ctx, msg.Mcid); err != nil {
+		log.Errorf("recovering pending message %s failed: %s", msg.Mcid, err)
+	}
 }
 
 // AllocateLane adds a new lane to a payment channel entry
@@ -121,6 +185,14 @@ func (pm *Manager) GetPaymentChannelInfo(paychAddr address.Address) (*ChannelInf
 // If successful, a new payment channel entry will be persisted to the
 // paymentChannels via a message wait handler.  Returns the created payment channel address
 func (pm *Manager) CreatePaymentChannel(client, miner address.Address, amt abi.TokenAmount) (address.Address, cid.Cid, error) {
+	return pm.createPaymentChannel(client, miner, amt, zeroAmt)
+}
+
+// createPaymentChannel sends the InitActor.Exec message creating a paych.Actor,
+// then arranges for the resulting ChannelInfo to be created with reserve
+// already reserved against it, avoiding a second, independent wait on the
+// same message to apply the reservation.
+func (pm *Manager) createPaymentChannel(client, miner address.Address, amt, reserve abi.TokenAmount) (address.Address, cid.Cid, error) {
 	errReturn := func(err error) (address.Address, cid.Cid, error) {
 		return address.Undef, cid.Undef, err
 	}
@@ -152,10 +224,285 @@ func (pm *Manager) CreatePaymentChannel(client, miner address.Address, amt abi.T
 	if err != nil {
 		return errReturn(err)
 	}
-	go pm.handlePaychCreateResult(pm.ctx, mcid, client, miner)
+
+	pending := PendingMessage{Mcid: mcid, Kind: PendingMsgCreate, From: client, To: miner, Amt: amt, Reserve: reserve}
+	if err := pm.pendingMessages.Begin(mcid, &pending); err != nil {
+		return errReturn(err)
+	}
+	w := pm.beginWait(mcid)
+	go func() {
+		if _, err := pm.finishWait(mcid, w, pm.handlePaychCreateResult(pm.ctx, mcid, client, miner, reserve)); err != nil {
+			log.Errorf("payment channel creation failed because: %s", err)
+		}
+	}()
 	return address.Undef, mcid, nil
 }
 
+// GetOrCreatePaymentChannel returns a payment channel address between client
+// and miner with amt reserved against it for the caller's exclusive use. If
+// an existing channel already has enough available (unreserved) balance,
+// amt is reserved immediately and the returned cid is cid.Undef. Otherwise,
+// any already-available balance is reserved immediately, a create or
+// add-funds message is sent for the shortfall, the remaining shortfall is
+// reserved once that message lands on chain, and the sent message's cid is
+// returned so the caller may block on it if needed. When no channel exists
+// yet and a create message is sent, the returned address is address.Undef.
+func (pm *Manager) GetOrCreatePaymentChannel(client, miner address.Address, amt abi.TokenAmount) (address.Address, cid.Cid, error) {
+	return pm.getOrCreatePaymentChannel(client, miner, amt, false)
+}
+
+// PaychGet returns the address of a payment channel from client to miner
+// with at least amt reserved for the caller's exclusive use, without
+// blocking on any on-chain message. It shares its reservation accounting
+// with GetOrCreatePaymentChannel, so concurrent PaychGet and
+// GetOrCreatePaymentChannel callers on the same channel can't oversubscribe
+// it. If an existing channel already has enough available balance, amt is
+// reserved and its address is returned directly. Otherwise, unless
+// opts.OffChain is true, any available balance is reserved synchronously, a
+// create or add-funds message is sent for the shortfall, and its cid is
+// returned; callers should pass that cid to PaychGetWaitReady to block until
+// the remaining reservation lands and the channel is ready. If
+// opts.OffChain is true and no existing channel can cover amt,
+// ErrChannelNotReady is returned instead of sending any message.
+func (pm *Manager) PaychGet(ctx context.Context, client, miner address.Address, amt abi.TokenAmount, opts PaychGetOpts) (address.Address, cid.Cid, error) {
+	return pm.getOrCreatePaymentChannel(client, miner, amt, opts.OffChain)
+}
+
+// getOrCreatePaymentChannel is the reservation-aware implementation shared
+// by GetOrCreatePaymentChannel and PaychGet. If offChain is true, it never
+// sends an on-chain message, returning ErrChannelNotReady instead of
+// creating a channel or adding funds when the existing balance can't cover
+// amt.
+func (pm *Manager) getOrCreatePaymentChannel(client, miner address.Address, amt abi.TokenAmount, offChain bool) (address.Address, cid.Cid, error) {
+	chinfo, err := pm.GetPaymentChannelByAccounts(client, miner)
+	if err != nil {
+		return address.Undef, cid.Undef, err
+	}
+
+	if chinfo.IsZero() {
+		if offChain {
+			return address.Undef, cid.Undef, ErrChannelNotReady
+		}
+		_, mcid, err := pm.createPaymentChannel(client, miner, amt, amt)
+		if err != nil {
+			return address.Undef, cid.Undef, err
+		}
+		return address.Undef, mcid, nil
+	}
+
+	balance, err := pm.channelBalance(chinfo.UniqueAddr)
+	if err != nil {
+		return address.Undef, cid.Undef, err
+	}
+
+	full, err := pm.tryReserveFull(chinfo.UniqueAddr, balance, amt)
+	if err != nil {
+		return address.Undef, cid.Undef, err
+	}
+	if full {
+		return chinfo.UniqueAddr, cid.Undef, nil
+	}
+	if offChain {
+		return address.Undef, cid.Undef, ErrChannelNotReady
+	}
+
+	// Reserve whatever portion of amt the balance currently covers now, in
+	// the same Mutate that reads it, so a concurrent caller can't also spend
+	// it while the shortfall is in flight; only the shortfall itself has to
+	// wait for its message to land.
+	available, err := pm.reserveAvailable(chinfo.UniqueAddr, balance, amt)
+	if err != nil {
+		return address.Undef, cid.Undef, err
+	}
+
+	shortfall := big.Sub(amt, available)
+	mcid, err := pm.AddFundsToChannel(chinfo.UniqueAddr, shortfall)
+	if err != nil {
+		return address.Undef, cid.Undef, err
+	}
+	w := pm.beginWait(mcid)
+	go func() {
+		if _, err := pm.finishWait(mcid, w, pm.waitAddFunds(pm.ctx, chinfo.UniqueAddr, mcid, shortfall)); err != nil {
+			log.Errorf("add funds to %s failed because: %s", chinfo.UniqueAddr, err)
+		}
+	}()
+	return chinfo.UniqueAddr, mcid, nil
+}
+
+// PaychGetWaitReady blocks until mcid, a create or add-funds message
+// returned by PaychGet or GetOrCreatePaymentChannel, appears on chain, then
+// returns the resulting channel's address. If the background goroutine
+// spawned when mcid's message was sent is already waiting on it, this joins
+// that wait rather than invoking waiter.Wait a second time, which would
+// double-apply the message's effects (reservation bumps, pendingMessages
+// bookkeeping). Otherwise, e.g. because mcid is being recovered after a
+// restart, it reconstructs the wait from the persisted pendingMessages
+// record. Once mcid's wait has completed, its pendingMessages record and
+// in-flight wait are both gone, so a later call for mcid returns an error
+// instead of the channel's address.
+func (pm *Manager) PaychGetWaitReady(ctx context.Context, mcid cid.Cid) (address.Address, error) {
+	return pm.awaitPending(ctx, mcid)
+}
+
+// beginWait registers mcid as having an in-flight wait, returning the
+// existing pendingWait if one is already registered (e.g. a concurrent
+// caller beat this one to it) rather than creating a second one. Callers
+// that register a fresh pendingWait are responsible for calling finishWait
+// on it once the wait they're performing completes.
+func (pm *Manager) beginWait(mcid cid.Cid) *pendingWait {
+	pm.pendingMu.Lock()
+	defer pm.pendingMu.Unlock()
+	w, ok := pm.pending[mcid]
+	if !ok {
+		w = &pendingWait{done: make(chan struct{})}
+		pm.pending[mcid] = w
+	}
+	return w
+}
+
+// finishWait records addr/err as w's result, removes mcid from pm.pending so
+// the map doesn't grow without bound over the life of the process, and then
+// wakes any goroutine blocked in joinWait(w), so a joiner that returns no
+// longer finds mcid in pm.pending. Joiners already blocked on w.done still
+// observe the result: they hold w directly, not a map lookup.
+func (pm *Manager) finishWait(mcid cid.Cid, w *pendingWait, addr address.Address, err error) (address.Address, error) {
+	w.addr, w.err = addr, err
+	pm.pendingMu.Lock()
+	delete(pm.pending, mcid)
+	pm.pendingMu.Unlock()
+	close(w.done)
+	return addr, err
+}
+
+// joinWait blocks until w's wait completes and returns its result.
+func (pm *Manager) joinWait(w *pendingWait) (address.Address, error) {
+	<-w.done
+	return w.addr, w.err
+}
+
+// awaitPending returns mcid's result, joining an already in-flight wait
+// registered by beginWait (from the goroutine spawned when mcid's message
+// was sent, or an earlier call to awaitPending for the same mcid) if one
+// exists. Otherwise it reconstructs the wait from mcid's persisted
+// pendingMessages record, as needed after a restart when no such goroutine
+// is running in this process.
+func (pm *Manager) awaitPending(ctx context.Context, mcid cid.Cid) (address.Address, error) {
+	pm.pendingMu.Lock()
+	if w, ok := pm.pending[mcid]; ok {
+		pm.pendingMu.Unlock()
+		return pm.joinWait(w)
+	}
+
+	var msg PendingMessage
+	if err := pm.pendingMessages.Get(mcid).Get(&msg); err != nil {
+		pm.pendingMu.Unlock()
+		return address.Undef, xerrors.Errorf("no pending payment channel message for %s: %s", mcid, err)
+	}
+	w := &pendingWait{done: make(chan struct{})}
+	pm.pending[mcid] = w
+	pm.pendingMu.Unlock()
+
+	switch msg.Kind {
+	case PendingMsgCreate:
+		return pm.finishWait(mcid, w, pm.handlePaychCreateResult(ctx, mcid, msg.From, msg.To, msg.Reserve))
+	case PendingMsgAddFunds:
+		return pm.finishWait(mcid, w, pm.waitAddFunds(ctx, msg.To, mcid, msg.Amt))
+	default:
+		return pm.finishWait(mcid, w, address.Undef, xerrors.Errorf("unrecognized pending message kind for %s", mcid))
+	}
+}
+
+// ReleaseFunds releases amt of paychAddr's reserved balance. Callers that
+// reserved funds via GetOrCreatePaymentChannel for a retrieval that was
+// subsequently aborted should call this to make the funds available again.
+func (pm *Manager) ReleaseFunds(paychAddr address.Address, amt abi.TokenAmount) error {
+	return pm.paymentChannels.Get(paychAddr).Mutate(func(info *ChannelInfo) error {
+		if amt.GreaterThan(info.ReservedAmount) {
+			return xerrors.Errorf("cannot release %s, only %s is reserved on %s", amt, info.ReservedAmount, paychAddr)
+		}
+		info.ReservedAmount = big.Sub(info.ReservedAmount, amt)
+		return nil
+	})
+}
+
+// reserveFunds increases paychAddr's reserved balance by amt.
+func (pm *Manager) reserveFunds(paychAddr address.Address, amt abi.TokenAmount) error {
+	return pm.paymentChannels.Get(paychAddr).Mutate(func(info *ChannelInfo) error {
+		info.ReservedAmount = big.Add(info.ReservedAmount, amt)
+		return nil
+	})
+}
+
+// channelBalance returns paychAddr's current on-chain balance.
+func (pm *Manager) channelBalance(paychAddr address.Address) (abi.TokenAmount, error) {
+	view, err := pm.stateViewer.GetStateView(pm.ctx, nil)
+	if err != nil {
+		return zeroAmt, err
+	}
+	return view.PaychActorBalance(pm.ctx, paychAddr)
+}
+
+// availableBalance returns info's on-chain balance less any amount already
+// reserved or in flight to it via a pending add-funds message.
+func availableBalance(info *ChannelInfo, balance abi.TokenAmount) abi.TokenAmount {
+	committed := big.Add(info.ReservedAmount, info.PendingAmount)
+	if committed.GreaterThan(balance) {
+		return zeroAmt
+	}
+	return big.Sub(balance, committed)
+}
+
+// tryReserveFull atomically reserves amt against paychAddr if balance, read
+// immediately before this call, currently covers it once ReservedAmount and
+// PendingAmount (re-read inside the same Mutate) are accounted for. The
+// balance check and the reservation happen in one Mutate so a concurrent
+// caller can't reserve the same headroom in between.
+func (pm *Manager) tryReserveFull(paychAddr address.Address, balance, amt abi.TokenAmount) (bool, error) {
+	var reserved bool
+	err := pm.paymentChannels.Get(paychAddr).Mutate(func(info *ChannelInfo) error {
+		if amt.GreaterThan(availableBalance(info, balance)) {
+			return nil
+		}
+		info.ReservedAmount = big.Add(info.ReservedAmount, amt)
+		reserved = true
+		return nil
+	})
+	return reserved, err
+}
+
+// reserveAvailable atomically reserves up to amt of paychAddr's available
+// balance (balance, read immediately before this call, less ReservedAmount
+// and PendingAmount re-read inside the same Mutate), returning the amount
+// actually reserved. Like tryReserveFull, the check and the reservation
+// happen in one Mutate so a concurrent caller can't claim the same headroom.
+func (pm *Manager) reserveAvailable(paychAddr address.Address, balance, amt abi.TokenAmount) (abi.TokenAmount, error) {
+	var reserved abi.TokenAmount
+	err := pm.paymentChannels.Get(paychAddr).Mutate(func(info *ChannelInfo) error {
+		reserved = amt
+		if have := availableBalance(info, balance); have.LessThan(amt) {
+			reserved = have
+		}
+		info.ReservedAmount = big.Add(info.ReservedAmount, reserved)
+		return nil
+	})
+	return reserved, err
+}
+
+// waitAddFunds waits for mcid, an add-funds message sent by
+// AddFundsToChannel for the shortfall GetOrCreatePaymentChannel/PaychGet
+// couldn't cover from the already-available balance, to land, then reserves
+// shortfall against paychAddr. The already-available portion of the balance
+// is reserved synchronously by the caller before this is invoked.
+func (pm *Manager) waitAddFunds(ctx context.Context, paychAddr address.Address, mcid cid.Cid, shortfall abi.TokenAmount) (address.Address, error) {
+	if err := pm.WaitForAddFundsMessage(ctx, paychAddr, mcid, shortfall); err != nil {
+		return address.Undef, err
+	}
+	if err := pm.reserveFunds(paychAddr, shortfall); err != nil {
+		return address.Undef, err
+	}
+	return paychAddr, nil
+}
+
 // AddVoucherToChannel saves a new signed voucher entry to the payment store
 // Assumes paychAddr channel has already been created.
 // Called by retrieval client connector
@@ -172,6 +519,11 @@ func (pm *Manager) AddVoucher(paychAddr address.Address, voucher *paychActor.Sig
 	if err != nil {
 		return zeroAmt, err
 	}
+
+	if err := pm.verifyVoucher(paychAddr, voucher, has, tok); err != nil {
+		return zeroAmt, err
+	}
+
 	if !has {
 		return pm.createPaymentChannelWithVoucher(paychAddr, voucher, proof, tok)
 	}
@@ -226,6 +578,66 @@ func (pm *Manager) GetMinerWorkerAddress(ctx context.Context, miner address.Addr
 	return fcworker, err
 }
 
+// verifyVoucher checks that voucher's signature was produced by paychAddr's
+// From account, that its TimeLockMin/TimeLockMax window contains the current
+// chain head, and, if it carries Merges, that every merged lane already
+// exists on paychAddr. existing indicates whether paychAddr already has a
+// store entry; a brand new channel cannot yet have any lanes to merge into.
+func (pm *Manager) verifyVoucher(paychAddr address.Address, voucher *paychActor.SignedVoucher, existing bool, tok shared.TipSetToken) error {
+	view, err := pm.stateViewer.GetStateView(pm.ctx, tok)
+	if err != nil {
+		return err
+	}
+
+	from, _, err := view.PaychActorParties(pm.ctx, paychAddr)
+	if err != nil {
+		return err
+	}
+	signerAddr, err := view.AccountSignerAddress(pm.ctx, from)
+	if err != nil {
+		return err
+	}
+
+	if voucher.Signature == nil {
+		return xerrors.Errorf("voucher has no signature")
+	}
+	signingBytes, err := voucher.SigningBytes()
+	if err != nil {
+		return err
+	}
+	if err := crypto.Verify(voucher.Signature, signerAddr, signingBytes); err != nil {
+		return xerrors.Errorf("voucher signature invalid: %s", err)
+	}
+
+	head, err := view.ChainHeadHeight(pm.ctx)
+	if err != nil {
+		return err
+	}
+	if voucher.TimeLockMin != 0 && voucher.TimeLockMin > head {
+		return xerrors.Errorf("voucher TimeLockMin %d is after chain head %d", voucher.TimeLockMin, head)
+	}
+	if voucher.TimeLockMax != 0 && voucher.TimeLockMax < head {
+		return xerrors.Errorf("voucher TimeLockMax %d has already elapsed at chain head %d", voucher.TimeLockMax, head)
+	}
+
+	if len(voucher.Merges) == 0 {
+		return nil
+	}
+	if !existing {
+		return xerrors.Errorf("voucher merges into lanes but channel %s does not yet exist", paychAddr)
+	}
+	chinfo, err := pm.GetPaymentChannelInfo(paychAddr)
+	if err != nil {
+		return err
+	}
+	for _, merge := range voucher.Merges {
+		if merge.Lane >= chinfo.NextLane {
+			return xerrors.Errorf("voucher merges into lane %d which does not exist", merge.Lane)
+		}
+	}
+	return nil
+}
+
 func (pm *Manager) WaitForCreatePaychMessage(ctx context.Context, mcid cid.Cid) (address.Address, error) {
 	var newPaychAddr address.Address
 
@@ -267,12 +679,35 @@ func (pm *Manager) AddFundsToChannel(paychAddr address.Address, amt abi.TokenAmo
 	if err != nil {
 		return cid.Undef, err
 	}
-	// TODO: track amts in paych store
+
+	pending := PendingMessage{Mcid: mcid, Kind: PendingMsgAddFunds, From: chinfo.From, To: paychAddr, Amt: amt}
+	if err := pm.pendingMessages.Begin(mcid, &pending); err != nil {
+		return cid.Undef, err
+	}
+
+	if err := pm.paymentChannels.Get(paychAddr).Mutate(func(info *ChannelInfo) error {
+		info.PendingAmount = big.Add(info.PendingAmount, amt)
+		return nil
+	}); err != nil {
+		return cid.Undef, err
+	}
 	return mcid, nil
 }
 
-func (pm *Manager) WaitForAddFundsMessage(ctx context.Context, mcid cid.Cid) error {
+// WaitForAddFundsMessage blocks until mcid, a message sent by
+// AddFundsToChannel, appears on chain, then clears paychAddr's pending
+// amount and the persisted pendingMessages record for mcid.
+func (pm *Manager) WaitForAddFundsMessage(ctx context.Context, paychAddr address.Address, mcid cid.Cid, amt abi.TokenAmount) error {
 	handleResult := func(b *block.Block, sm *types.SignedMessage, mr *vm.MessageReceipt) error {
+		if err := pm.paymentChannels.Get(paychAddr).Mutate(func(info *ChannelInfo) error {
+			info.PendingAmount = big.Sub(info.PendingAmount, amt)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := pm.pendingMessages.Get(mcid).End(); err != nil {
+			return err
+		}
 		if mr.ExitCode != exitcode.Ok {
 			return xerrors.Errorf("Add funds failed with exitcode %d", mr.ExitCode)
 		}
@@ -281,15 +716,18 @@ func (pm *Manager) WaitForAddFundsMessage(ctx context.Context, mcid cid.Cid) err
 	return pm.waiter.Wait(pm.ctx, mcid, handleResult)
 }
 
-// WaitForPaychCreateMsg waits for mcid to appear on chain and returns the robust address of the
-// created payment channel
+// handlePaychCreateResult waits for mcid, the InitActor.Exec message sent by
+// createPaymentChannel, to appear on chain, then persists the new channel's
+// ChannelInfo with reserve already reserved against it and clears mcid's
+// pendingMessages record. It returns the created channel's address.
 // TODO: paych store locking, wait outside store lock, also set up channel tracking somehow
 // before knowing paych addr
-func (pm *Manager) handlePaychCreateResult(ctx context.Context, mcid cid.Cid, client, miner address.Address) {
+func (pm *Manager) handlePaychCreateResult(ctx context.Context, mcid cid.Cid, client, miner address.Address, reserve abi.TokenAmount) (address.Address, error) {
+	var newPaychAddr address.Address
 
 	handleResult := func(_ *block.Block, _ *types.SignedMessage, mr *vm.MessageReceipt) error {
-		if mr.ExitCode != 0 {
-			log.Errorf("create message failed with exit code %d", mr.ExitCode)
+		if mr.ExitCode != exitcode.Ok {
+			return xerrors.Errorf("create message failed with exit code %d", mr.ExitCode)
 		}
 
 		var decodedReturn initActor.ExecReturn
@@ -300,18 +738,30 @@ func (pm *Manager) handlePaychCreateResult(ctx context.Context, mcid cid.Cid, cl
 
 		// TODO check again to make sure a payment channel has not been created for this From/To
 		chinfo := ChannelInfo{
-			From:       client,
-			To:         miner,
-			NextLane:   0,
-			NextNonce:  1,
-			UniqueAddr: paychAddr,
+			From:            client,
+			To:              miner,
+			NextLane:        0,
+			NextNonce:       1,
+			UniqueAddr:      paychAddr,
+			AmountRedeemed:  zeroAmt,
+			PendingAmount:   zeroAmt,
+			ReservedAmount:  reserve,
+			SubmittedAmount: zeroAmt,
+		}
+		if err := pm.paymentChannels.Begin(paychAddr, &chinfo); err != nil {
+			return err
+		}
+		if err := pm.pendingMessages.Get(mcid).End(); err != nil {
+			return err
 		}
-		return pm.paymentChannels.Begin(paychAddr, &chinfo)
+		newPaychAddr = paychAddr
+		return nil
 	}
 
 	if err := pm.waiter.Wait(ctx, mcid, handleResult); err != nil {
-		log.Errorf("payment channel creation failed because: %w", err)
+		return address.Undef, err
 	}
+	return newPaychAddr, nil
 }
 
 // Called ONLY in context of a retrieval provider.
@@ -327,12 +777,16 @@ func (pm *Manager) createPaymentChannelWithVoucher(paychAddr address.Address, vo
 	// needs to "allocate" a lane as well as storing a voucher so this bumps
 	// lane once and nonce twice
 	chinfo := ChannelInfo{
-		From:       from,
-		To:         to,
-		NextLane:   1,
-		NextNonce:  2,
-		UniqueAddr: paychAddr,
-		Vouchers:   []*VoucherInfo{{Voucher: voucher, Proof: proof}},
+		From:            from,
+		To:              to,
+		NextLane:        1,
+		NextNonce:       2,
+		UniqueAddr:      paychAddr,
+		Vouchers:        []*VoucherInfo{{Voucher: voucher, Proof: proof}},
+		AmountRedeemed:  zeroAmt,
+		PendingAmount:   zeroAmt,
+		ReservedAmount:  zeroAmt,
+		SubmittedAmount: zeroAmt,
 	}
 	if err = pm.paymentChannels.Begin(paychAddr, &chinfo); err != nil {
 		return zeroAmt, err
@@ -340,6 +794,193 @@ func (pm *Manager) createPaymentChannelWithVoucher(paychAddr address.Address, vo
 	return voucher.Amount, nil
 }
 
+// SubmitVoucher redeems paychAddr's largest stored voucher for voucher's lane
+// (which may or may not be voucher itself) by sending a single
+// paychActor.MethodsPaych.UpdateChannelState message, which carries at most
+// one Sv. To redeem vouchers on multiple lanes, call SubmitVoucher once per
+// lane in ChannelInfo.LanesWithVouchers, or sign a voucher whose Merges
+// field consolidates them into a single Sv instead. Callers should use
+// WaitForSubmit to reconcile ChannelInfo once the message lands.
+func (pm *Manager) SubmitVoucher(paychAddr address.Address, voucher *paychActor.SignedVoucher, secret []byte, proof []byte) (cid.Cid, error) {
+	chinfo, err := pm.GetPaymentChannelInfo(paychAddr)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	best := chinfo.bestVoucherForLane(voucher.Lane)
+	if best == nil || voucher.Amount.GreaterThan(best.Amount) {
+		best = voucher
+	}
+
+	updateParams := &paychActor.UpdateChannelStateParams{
+		Sv:     *best,
+		Secret: secret,
+	}
+	mcid, _, err := pm.sender.Send(
+		pm.ctx,
+		chinfo.From,
+		paychAddr,
+		zeroAmt,
+		defaultGasPrice,
+		defaultGasLimit,
+		true,
+		builtin.MethodsPaych.UpdateChannelState,
+		updateParams,
+	)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	if err := pm.paymentChannels.Get(paychAddr).Mutate(func(info *ChannelInfo) error {
+		info.SubmittedMsg = mcid
+		info.SubmittedAmount = best.Amount
+		return nil
+	}); err != nil {
+		return cid.Undef, err
+	}
+	return mcid, nil
+}
+
+// WaitForSubmit blocks until mcid, a message sent by SubmitVoucher, appears
+// on chain, then reconciles paychAddr's ChannelInfo with the redemption: the
+// newly-redeemed portion of the submitted voucher (its Amount less whatever
+// was already reflected in AmountRedeemed) is added to AmountRedeemed and
+// released from ReservedAmount, since that amount has now actually left the
+// channel rather than merely being promised to a future voucher.
+func (pm *Manager) WaitForSubmit(ctx context.Context, paychAddr address.Address, mcid cid.Cid) error {
+	handleResult := func(_ *block.Block, _ *types.SignedMessage, mr *vm.MessageReceipt) error {
+		if err := pm.paymentChannels.Get(paychAddr).Mutate(func(info *ChannelInfo) error {
+			if mr.ExitCode == exitcode.Ok {
+				redeemed := big.Sub(info.SubmittedAmount, info.AmountRedeemed)
+				if redeemed.GreaterThan(zeroAmt) {
+					info.AmountRedeemed = info.SubmittedAmount
+					if redeemed.GreaterThan(info.ReservedAmount) {
+						redeemed = info.ReservedAmount
+					}
+					info.ReservedAmount = big.Sub(info.ReservedAmount, redeemed)
+				}
+			}
+			info.SubmittedMsg = cid.Undef
+			info.SubmittedAmount = zeroAmt
+			return nil
+		}); err != nil {
+			return err
+		}
+		if mr.ExitCode != exitcode.Ok {
+			return xerrors.Errorf("submit voucher message failed with exitcode %d", mr.ExitCode)
+		}
+		return nil
+	}
+	return pm.waiter.Wait(ctx, mcid, handleResult)
+}
+
+// SettleChannel sends a MethodsPaych.Settle message for paychAddr, starting
+// the channel's settlement delay. Callers should use WaitForSettle to block
+// until the message lands before calling CollectChannel.
+func (pm *Manager) SettleChannel(paychAddr address.Address) (cid.Cid, error) {
+	chinfo, err := pm.GetPaymentChannelInfo(paychAddr)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	mcid, _, err := pm.sender.Send(
+		pm.ctx,
+		chinfo.From,
+		paychAddr,
+		zeroAmt,
+		defaultGasPrice,
+		defaultGasLimit,
+		true,
+		builtin.MethodsPaych.Settle,
+		nil,
+	)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	if err := pm.paymentChannels.Get(paychAddr).Mutate(func(info *ChannelInfo) error {
+		info.SettleMsg = mcid
+		return nil
+	}); err != nil {
+		return cid.Undef, err
+	}
+	return mcid, nil
+}
+
+// CollectChannel sends a MethodsPaych.Collect message for paychAddr, paying
+// out the channel's remaining balance. The channel must already be settled;
+// the actor itself enforces that the settlement delay has elapsed.
+func (pm *Manager) CollectChannel(paychAddr address.Address) (cid.Cid, error) {
+	chinfo, err := pm.GetPaymentChannelInfo(paychAddr)
+	if err != nil {
+		return cid.Undef, err
+	}
+	if !chinfo.Settled {
+		return cid.Undef, xerrors.Errorf("payment channel %s is not settled", paychAddr)
+	}
+
+	mcid, _, err := pm.sender.Send(
+		pm.ctx,
+		chinfo.From,
+		paychAddr,
+		zeroAmt,
+		defaultGasPrice,
+		defaultGasLimit,
+		true,
+		builtin.MethodsPaych.Collect,
+		nil,
+	)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	if err := pm.paymentChannels.Get(paychAddr).Mutate(func(info *ChannelInfo) error {
+		info.CollectMsg = mcid
+		return nil
+	}); err != nil {
+		return cid.Undef, err
+	}
+	return mcid, nil
+}
+
+// WaitForSettle blocks until mcid, a message sent by SettleChannel, appears
+// on chain, then marks paychAddr's channel as settled.
+func (pm *Manager) WaitForSettle(ctx context.Context, paychAddr address.Address, mcid cid.Cid) error {
+	handleResult := func(_ *block.Block, _ *types.SignedMessage, mr *vm.MessageReceipt) error {
+		if err := pm.paymentChannels.Get(paychAddr).Mutate(func(info *ChannelInfo) error {
+			info.Settled = mr.ExitCode == exitcode.Ok
+			info.SettleMsg = cid.Undef
+			return nil
+		}); err != nil {
+			return err
+		}
+		if mr.ExitCode != exitcode.Ok {
+			return xerrors.Errorf("settle message failed with exitcode %d", mr.ExitCode)
+		}
+		return nil
+	}
+	return pm.waiter.Wait(ctx, mcid, handleResult)
+}
+
+// WaitForCollect blocks until mcid, a message sent by CollectChannel,
+// appears on chain, then marks paychAddr's channel as collected.
+func (pm *Manager) WaitForCollect(ctx context.Context, paychAddr address.Address, mcid cid.Cid) error {
+	handleResult := func(_ *block.Block, _ *types.SignedMessage, mr *vm.MessageReceipt) error {
+		if err := pm.paymentChannels.Get(paychAddr).Mutate(func(info *ChannelInfo) error {
+			info.Collected = mr.ExitCode == exitcode.Ok
+			info.CollectMsg = cid.Undef
+			return nil
+		}); err != nil {
+			return err
+		}
+		if mr.ExitCode != exitcode.Ok {
+			return xerrors.Errorf("collect message failed with exitcode %d", mr.ExitCode)
+		}
+		return nil
+	}
+	return pm.waiter.Wait(ctx, mcid, handleResult)
+}
+
 // saveNewVoucher saves a voucher to an existing payment channel
 func (pm *Manager) saveNewVoucher(paychAddr address.Address, voucher *paychActor.SignedVoucher, proof []byte) error {
 	var chinfo ChannelInfo
@@ -0,0 +1,543 @@
+package paymentchannel
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-fil-markets/shared"
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	initActor "github.com/filecoin-project/specs-actors/actors/builtin/init"
+	paychActor "github.com/filecoin-project/specs-actors/actors/builtin/paych"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/internal/pkg/block"
+	"github.com/filecoin-project/go-filecoin/internal/pkg/types"
+	"github.com/filecoin-project/go-filecoin/internal/pkg/vm"
+	"github.com/filecoin-project/go-filecoin/internal/pkg/vm/gas"
+)
+
+// noopSender and noopWaiter satisfy MsgSender and MsgWaiter for tests that
+// don't exercise any on-chain messaging.
+type noopSender struct{}
+
+func (noopSender) Send(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit gas.Unit, bcast bool, method abi.MethodNum, params interface{}) (cid.Cid, chan error, error) {
+	return cid.Undef, nil, nil
+}
+
+type noopWaiter struct{}
+
+func (noopWaiter) Wait(ctx context.Context, msgCid cid.Cid, cb func(*block.Block, *types.SignedMessage, *vm.MessageReceipt) error) error {
+	return nil
+}
+
+// syncWaiter invokes cb synchronously with a fixed receipt, then closes done,
+// so a test can block until a goroutine that calls Wait has finished. If
+// release is non-nil, Wait first blocks until it is closed, so a test can act
+// while the wait is still in flight. Each syncWaiter serves a single Wait.
+type syncWaiter struct {
+	mr      *vm.MessageReceipt
+	done    chan struct{}
+	release chan struct{}
+}
+
+func (w *syncWaiter) Wait(ctx context.Context, msgCid cid.Cid, cb func(*block.Block, *types.SignedMessage, *vm.MessageReceipt) error) error {
+	defer close(w.done)
+	if w.release != nil {
+		<-w.release
+	}
+	return cb(nil, nil, w.mr)
+}
+
+// fakeStateView is a minimal ManagerStateView stand-in that returns a fixed
+// set of answers configured per test.
+type fakeStateView struct {
+	from, to address.Address
+	signer   address.Address
+	head     abi.ChainEpoch
+}
+
+func (f *fakeStateView) MinerControlAddresses(ctx context.Context, miner address.Address) (address.Address, address.Address, error) {
+	return address.Undef, address.Undef, nil
+}
+
+func (f *fakeStateView) PaychActorParties(ctx context.Context, paychAddr address.Address) (address.Address, address.Address, error) {
+	return f.from, f.to, nil
+}
+
+func (f *fakeStateView) AccountSignerAddress(ctx context.Context, accountAddr address.Address) (address.Address, error) {
+	return f.signer, nil
+}
+
+func (f *fakeStateView) ChainHeadHeight(ctx context.Context) (abi.ChainEpoch, error) {
+	return f.head, nil
+}
+
+func (f *fakeStateView) PaychActorBalance(ctx context.Context, paychAddr address.Address) (abi.TokenAmount, error) {
+	return zeroAmt, nil
+}
+
+// balanceView overrides fakeStateView's PaychActorBalance with a fixed
+// configurable balance, for tests that need a non-zero on-chain balance to
+// exercise reservation accounting.
+type balanceView struct {
+	*fakeStateView
+	balance abi.TokenAmount
+}
+
+func (b *balanceView) PaychActorBalance(ctx context.Context, paychAddr address.Address) (abi.TokenAmount, error) {
+	return b.balance, nil
+}
+
+type fakeViewer struct {
+	view ManagerStateView
+}
+
+func (f *fakeViewer) GetStateView(ctx context.Context, tok shared.TipSetToken) (ManagerStateView, error) {
+	return f.view, nil
+}
+
+func newTestManager(t *testing.T, view ManagerStateView) *Manager {
+	t.Helper()
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	return NewManager(context.Background(), ds, noopWaiter{}, noopSender{}, &fakeViewer{view: view})
+}
+
+func signedVoucher(t *testing.T, paychAddr address.Address, signer *types.MockSigner, signerAddr address.Address, amt abi.TokenAmount) *paychActor.SignedVoucher {
+	t.Helper()
+	voucher := &paychActor.SignedVoucher{
+		ChannelAddr: paychAddr,
+		Lane:        0,
+		Nonce:       1,
+		Amount:      amt,
+	}
+	signingBytes, err := voucher.SigningBytes()
+	require.NoError(t, err)
+	sig, err := signer.SignBytes(signingBytes, signerAddr)
+	require.NoError(t, err)
+	voucher.Signature = &sig
+	return voucher
+}
+
+// TestLanesWithVouchers proves that LanesWithVouchers returns every distinct
+// lane with a stored voucher, in ascending order, so a caller can redeem a
+// channel with outstanding vouchers on more than one lane by calling
+// SubmitVoucher once per lane.
+func TestLanesWithVouchers(t *testing.T) {
+	chinfo := ChannelInfo{
+		Vouchers: []*VoucherInfo{
+			{Voucher: &paychActor.SignedVoucher{Lane: 2, Nonce: 1, Amount: abi.NewTokenAmount(10)}},
+			{Voucher: &paychActor.SignedVoucher{Lane: 0, Nonce: 1, Amount: abi.NewTokenAmount(5)}},
+			{Voucher: &paychActor.SignedVoucher{Lane: 2, Nonce: 2, Amount: abi.NewTokenAmount(20)}},
+		},
+	}
+	assert.Equal(t, []uint64{0, 2}, chinfo.LanesWithVouchers())
+}
+
+func TestAddVoucherVerifiesSignature(t *testing.T) {
+	paychAddr, err := address.NewIDAddress(100)
+	require.NoError(t, err)
+	client, err := address.NewIDAddress(101)
+	require.NoError(t, err)
+	miner, err := address.NewIDAddress(102)
+	require.NoError(t, err)
+
+	ki := types.MustGenerateKeyInfo(2, 0)
+	signer := types.NewMockSigner(ki)
+	goodAddr, err := ki[0].Address()
+	require.NoError(t, err)
+	badAddr, err := ki[1].Address()
+	require.NoError(t, err)
+
+	amt := abi.NewTokenAmount(100)
+
+	t.Run("rejects a voucher signed by the wrong key", func(t *testing.T) {
+		pm := newTestManager(t, &fakeStateView{from: client, to: miner, signer: goodAddr})
+		voucher := signedVoucher(t, paychAddr, &signer, badAddr, amt)
+
+		_, err := pm.AddVoucher(paychAddr, voucher, nil, big.Zero(), nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("accepts a validly signed voucher", func(t *testing.T) {
+		pm := newTestManager(t, &fakeStateView{from: client, to: miner, signer: goodAddr})
+		voucher := signedVoucher(t, paychAddr, &signer, goodAddr, amt)
+
+		delta, err := pm.AddVoucher(paychAddr, voucher, nil, big.Zero(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, amt, delta)
+
+		has, err := pm.ChannelExists(paychAddr)
+		require.NoError(t, err)
+		assert.True(t, has)
+	})
+}
+
+// TestAddVoucherRejectsExpiredTimeLockMax proves that a voucher whose
+// TimeLockMax has already elapsed at the current chain head is rejected,
+// since the paych actor's own UpdateChannelState would always fail it.
+func TestAddVoucherRejectsExpiredTimeLockMax(t *testing.T) {
+	paychAddr, err := address.NewIDAddress(110)
+	require.NoError(t, err)
+	client, err := address.NewIDAddress(111)
+	require.NoError(t, err)
+	miner, err := address.NewIDAddress(112)
+	require.NoError(t, err)
+
+	ki := types.MustGenerateKeyInfo(1, 0)
+	signer := types.NewMockSigner(ki)
+	signerAddr, err := ki[0].Address()
+	require.NoError(t, err)
+
+	pm := newTestManager(t, &fakeStateView{from: client, to: miner, signer: signerAddr, head: 100})
+
+	voucher := &paychActor.SignedVoucher{
+		ChannelAddr: paychAddr,
+		Lane:        0,
+		Nonce:       1,
+		Amount:      abi.NewTokenAmount(100),
+		TimeLockMax: 50,
+	}
+	signingBytes, err := voucher.SigningBytes()
+	require.NoError(t, err)
+	sig, err := signer.SignBytes(signingBytes, signerAddr)
+	require.NoError(t, err)
+	voucher.Signature = &sig
+
+	_, err = pm.AddVoucher(paychAddr, voucher, nil, big.Zero(), nil)
+	assert.Error(t, err)
+}
+
+// TestPaychGetReservesAtomicallyUnderConcurrency proves that concurrent
+// PaychGet callers racing to reserve funds from the same channel's balance
+// can't each observe the same stale available balance and oversubscribe it;
+// exactly as many callers succeed as the balance can actually cover.
+func TestPaychGetReservesAtomicallyUnderConcurrency(t *testing.T) {
+	paychAddr, err := address.NewIDAddress(400)
+	require.NoError(t, err)
+	client, err := address.NewIDAddress(401)
+	require.NoError(t, err)
+	miner, err := address.NewIDAddress(402)
+	require.NoError(t, err)
+
+	view := &balanceView{fakeStateView: &fakeStateView{}, balance: abi.NewTokenAmount(100)}
+	pm := newTestManager(t, view)
+
+	chinfo := ChannelInfo{UniqueAddr: paychAddr, From: client, To: miner, AmountRedeemed: zeroAmt, PendingAmount: zeroAmt, ReservedAmount: zeroAmt}
+	require.NoError(t, pm.paymentChannels.Begin(paychAddr, &chinfo))
+
+	const callers = 10
+	amt := abi.NewTokenAmount(20)
+	var wg sync.WaitGroup
+	successes := make([]bool, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, err := pm.PaychGet(context.Background(), client, miner, amt, PaychGetOpts{OffChain: true})
+			successes[i] = err == nil
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, ok := range successes {
+		if ok {
+			succeeded++
+		}
+	}
+	assert.Equal(t, 5, succeeded, "only 5 of 10 callers should fit in a balance of 100 at 20 each")
+
+	got, err := pm.GetPaymentChannelInfo(paychAddr)
+	require.NoError(t, err)
+	assert.True(t, got.ReservedAmount.Equals(abi.NewTokenAmount(100)), "reserved amount should not exceed the channel's balance")
+}
+
+// TestPaychGetWaitReadyJoinsInFlightAddFunds proves that PaychGetWaitReady,
+// called on the cid returned by GetOrCreatePaymentChannel's add-funds path,
+// joins the wait the background goroutine spawned for that cid already
+// started rather than invoking WaitForAddFundsMessage a second time, which
+// would double-subtract PendingAmount and double-reserve the shortfall.
+func TestPaychGetWaitReadyJoinsInFlightAddFunds(t *testing.T) {
+	paychAddr, err := address.NewIDAddress(500)
+	require.NoError(t, err)
+	client, err := address.NewIDAddress(501)
+	require.NoError(t, err)
+	miner, err := address.NewIDAddress(502)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	release := make(chan struct{})
+	waiter := &syncWaiter{mr: &vm.MessageReceipt{ExitCode: exitcode.Ok}, done: done, release: release}
+	view := &balanceView{fakeStateView: &fakeStateView{}, balance: zeroAmt}
+	pm := NewManager(context.Background(), dssync.MutexWrap(datastore.NewMapDatastore()), waiter, noopSender{}, &fakeViewer{view: view})
+
+	chinfo := ChannelInfo{UniqueAddr: paychAddr, From: client, To: miner, AmountRedeemed: zeroAmt, PendingAmount: zeroAmt, ReservedAmount: zeroAmt}
+	require.NoError(t, pm.paymentChannels.Begin(paychAddr, &chinfo))
+
+	amt := abi.NewTokenAmount(20)
+	_, mcid, err := pm.GetOrCreatePaymentChannel(client, miner, amt)
+	require.NoError(t, err)
+
+	// The background wait is registered before GetOrCreatePaymentChannel
+	// returns and can't finish until release is closed, so PaychGetWaitReady
+	// finds it in flight and joins it.
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	addr, err := pm.PaychGetWaitReady(context.Background(), mcid)
+	require.NoError(t, err)
+	assert.Equal(t, paychAddr, addr)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the background add-funds wait to finish")
+	}
+
+	got, err := pm.GetPaymentChannelInfo(paychAddr)
+	require.NoError(t, err)
+	assert.True(t, got.PendingAmount.Equals(zeroAmt), "PendingAmount should be cleared exactly once, not driven negative by a duplicate wait")
+	assert.True(t, got.ReservedAmount.Equals(amt), "amt should be reserved exactly once, not doubled by a duplicate wait")
+
+	pm.pendingMu.Lock()
+	_, stillTracked := pm.pending[mcid]
+	pm.pendingMu.Unlock()
+	assert.False(t, stillTracked, "a resolved wait should be removed from pending, not retained for the life of the process")
+}
+
+// TestStartRecoversPendingMessages proves that a pending add-funds message
+// persisted before a restart is replayed by Start, clearing both the
+// channel's pending amount and the pendingMessages record once it lands.
+func TestStartRecoversPendingMessages(t *testing.T) {
+	paychAddr, err := address.NewIDAddress(200)
+	require.NoError(t, err)
+
+	mh, err := multihash.Sum([]byte("pending-add-funds"), multihash.SHA2_256, -1)
+	require.NoError(t, err)
+	mcid := cid.NewCidV1(cid.Raw, mh)
+
+	amt := abi.NewTokenAmount(50)
+	done := make(chan struct{})
+	waiter := &syncWaiter{mr: &vm.MessageReceipt{ExitCode: exitcode.Ok}, done: done}
+
+	pm := NewManager(context.Background(), dssync.MutexWrap(datastore.NewMapDatastore()), waiter, noopSender{}, &fakeViewer{view: &fakeStateView{}})
+
+	chinfo := ChannelInfo{UniqueAddr: paychAddr, PendingAmount: amt, AmountRedeemed: zeroAmt, ReservedAmount: zeroAmt}
+	require.NoError(t, pm.paymentChannels.Begin(paychAddr, &chinfo))
+
+	pending := PendingMessage{Mcid: mcid, Kind: PendingMsgAddFunds, To: paychAddr, Amt: amt}
+	require.NoError(t, pm.pendingMessages.Begin(mcid, &pending))
+
+	require.NoError(t, pm.Start(context.Background()))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for Start to recover the pending message")
+	}
+
+	got, err := pm.GetPaymentChannelInfo(paychAddr)
+	require.NoError(t, err)
+	assert.True(t, got.PendingAmount.Equals(zeroAmt))
+
+	has, err := pm.pendingMessages.Has(mcid)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+// TestWaitForAddFundsMessageClearsPendingOnFailure proves that when an
+// add-funds message lands with a non-OK exit code, WaitForAddFundsMessage
+// still clears PendingAmount and the pendingMessages record, rather than
+// leaving amt permanently counted against the channel's available balance
+// with no way for a landed message to be retried.
+func TestWaitForAddFundsMessageClearsPendingOnFailure(t *testing.T) {
+	paychAddr, err := address.NewIDAddress(700)
+	require.NoError(t, err)
+
+	mh, err := multihash.Sum([]byte("failed-add-funds"), multihash.SHA2_256, -1)
+	require.NoError(t, err)
+	mcid := cid.NewCidV1(cid.Raw, mh)
+
+	amt := abi.NewTokenAmount(50)
+	waiter := &syncWaiter{mr: &vm.MessageReceipt{ExitCode: exitcode.ExitCode(1)}, done: make(chan struct{})}
+	pm := NewManager(context.Background(), dssync.MutexWrap(datastore.NewMapDatastore()), waiter, noopSender{}, &fakeViewer{view: &fakeStateView{}})
+
+	chinfo := ChannelInfo{UniqueAddr: paychAddr, PendingAmount: amt, AmountRedeemed: zeroAmt, ReservedAmount: zeroAmt}
+	require.NoError(t, pm.paymentChannels.Begin(paychAddr, &chinfo))
+
+	pending := PendingMessage{Mcid: mcid, Kind: PendingMsgAddFunds, To: paychAddr, Amt: amt}
+	require.NoError(t, pm.pendingMessages.Begin(mcid, &pending))
+
+	err = pm.WaitForAddFundsMessage(context.Background(), paychAddr, mcid, amt)
+	require.Error(t, err)
+
+	got, err := pm.GetPaymentChannelInfo(paychAddr)
+	require.NoError(t, err)
+	assert.True(t, got.PendingAmount.Equals(zeroAmt), "PendingAmount should be cleared even though the message failed")
+
+	has, err := pm.pendingMessages.Has(mcid)
+	require.NoError(t, err)
+	assert.False(t, has, "pendingMessages record should be cleared even though the message failed")
+}
+
+// TestStartRecoversPendingCreateReserve proves that a pending create message
+// persisted with a non-zero Reserve survives a restart: recovering it
+// reserves the original amount against the newly created channel instead of
+// silently dropping it to zero.
+func TestStartRecoversPendingCreateReserve(t *testing.T) {
+	client, err := address.NewIDAddress(600)
+	require.NoError(t, err)
+	miner, err := address.NewIDAddress(601)
+	require.NoError(t, err)
+	paychAddr, err := address.NewIDAddress(602)
+	require.NoError(t, err)
+
+	mh, err := multihash.Sum([]byte("pending-create"), multihash.SHA2_256, -1)
+	require.NoError(t, err)
+	mcid := cid.NewCidV1(cid.Raw, mh)
+
+	execReturn := initActor.ExecReturn{IDAddress: paychAddr, RobustAddress: paychAddr}
+	buf := new(bytes.Buffer)
+	require.NoError(t, execReturn.MarshalCBOR(buf))
+
+	done := make(chan struct{})
+	waiter := &syncWaiter{mr: &vm.MessageReceipt{ExitCode: exitcode.Ok, ReturnValue: buf.Bytes()}, done: done}
+
+	pm := NewManager(context.Background(), dssync.MutexWrap(datastore.NewMapDatastore()), waiter, noopSender{}, &fakeViewer{view: &fakeStateView{}})
+
+	reserve := abi.NewTokenAmount(75)
+	pending := PendingMessage{Mcid: mcid, Kind: PendingMsgCreate, From: client, To: miner, Amt: reserve, Reserve: reserve}
+	require.NoError(t, pm.pendingMessages.Begin(mcid, &pending))
+
+	require.NoError(t, pm.Start(context.Background()))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for Start to recover the pending create message")
+	}
+
+	got, err := pm.GetPaymentChannelInfo(paychAddr)
+	require.NoError(t, err)
+	assert.True(t, got.ReservedAmount.Equals(reserve), "reserve should survive restart recovery, not be dropped to zero")
+}
+
+// TestWaitForSubmitReconcilesReservedAmount proves that once a submitted
+// voucher's UpdateChannelState message lands, WaitForSubmit moves its amount
+// from ReservedAmount into AmountRedeemed rather than leaving it stuck as
+// permanently committed.
+func TestWaitForSubmitReconcilesReservedAmount(t *testing.T) {
+	paychAddr, err := address.NewIDAddress(300)
+	require.NoError(t, err)
+	client, err := address.NewIDAddress(301)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	waiter := &syncWaiter{mr: &vm.MessageReceipt{ExitCode: exitcode.Ok}, done: done}
+	pm := NewManager(context.Background(), dssync.MutexWrap(datastore.NewMapDatastore()), waiter, noopSender{}, &fakeViewer{view: &fakeStateView{}})
+
+	voucherAmt := abi.NewTokenAmount(100)
+	voucher := &paychActor.SignedVoucher{Lane: 0, Nonce: 1, Amount: voucherAmt}
+	chinfo := ChannelInfo{
+		UniqueAddr:     paychAddr,
+		From:           client,
+		NextLane:       1,
+		NextNonce:      2,
+		Vouchers:       []*VoucherInfo{{Voucher: voucher}},
+		AmountRedeemed: zeroAmt,
+		PendingAmount:  zeroAmt,
+		ReservedAmount: voucherAmt,
+	}
+	require.NoError(t, pm.paymentChannels.Begin(paychAddr, &chinfo))
+
+	mcid, err := pm.SubmitVoucher(paychAddr, voucher, nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, pm.WaitForSubmit(context.Background(), paychAddr, mcid))
+
+	got, err := pm.GetPaymentChannelInfo(paychAddr)
+	require.NoError(t, err)
+	assert.True(t, got.AmountRedeemed.Equals(voucherAmt))
+	assert.True(t, got.ReservedAmount.Equals(zeroAmt))
+	assert.Equal(t, cid.Undef, got.SubmittedMsg)
+}
+
+// TestWaitForSubmitClearsSubmittedMsgOnFailure proves that a failed submit
+// message still clears SubmittedMsg/SubmittedAmount and leaves
+// AmountRedeemed/ReservedAmount untouched, rather than wedging the channel's
+// "message in flight" markers permanently after one failure.
+func TestWaitForSubmitClearsSubmittedMsgOnFailure(t *testing.T) {
+	paychAddr, err := address.NewIDAddress(800)
+	require.NoError(t, err)
+
+	waiter := &syncWaiter{mr: &vm.MessageReceipt{ExitCode: exitcode.ExitCode(1)}, done: make(chan struct{})}
+	pm := NewManager(context.Background(), dssync.MutexWrap(datastore.NewMapDatastore()), waiter, noopSender{}, &fakeViewer{view: &fakeStateView{}})
+
+	voucherAmt := abi.NewTokenAmount(100)
+	chinfo := ChannelInfo{
+		UniqueAddr:      paychAddr,
+		AmountRedeemed:  zeroAmt,
+		ReservedAmount:  voucherAmt,
+		SubmittedMsg:    cid.Undef,
+		SubmittedAmount: voucherAmt,
+	}
+	require.NoError(t, pm.paymentChannels.Begin(paychAddr, &chinfo))
+
+	mh, err := multihash.Sum([]byte("failed-submit"), multihash.SHA2_256, -1)
+	require.NoError(t, err)
+	mcid := cid.NewCidV1(cid.Raw, mh)
+
+	err = pm.WaitForSubmit(context.Background(), paychAddr, mcid)
+	require.Error(t, err)
+
+	got, err := pm.GetPaymentChannelInfo(paychAddr)
+	require.NoError(t, err)
+	assert.True(t, got.AmountRedeemed.Equals(zeroAmt), "a failed submit should not move anything into AmountRedeemed")
+	assert.True(t, got.ReservedAmount.Equals(voucherAmt), "a failed submit should not release ReservedAmount")
+	assert.Equal(t, cid.Undef, got.SubmittedMsg, "SubmittedMsg should be cleared even though the message failed")
+	assert.True(t, got.SubmittedAmount.Equals(zeroAmt), "SubmittedAmount should be cleared even though the message failed")
+}
+
+// TestWaitForSettleAndCollectClearMsgOnFailure proves that failed settle and
+// collect messages still clear SettleMsg/CollectMsg, rather than wedging a
+// retry helper that checks those fields to avoid double-sending.
+func TestWaitForSettleAndCollectClearMsgOnFailure(t *testing.T) {
+	paychAddr, err := address.NewIDAddress(801)
+	require.NoError(t, err)
+
+	waiter := &syncWaiter{mr: &vm.MessageReceipt{ExitCode: exitcode.ExitCode(1)}, done: make(chan struct{})}
+	pm := NewManager(context.Background(), dssync.MutexWrap(datastore.NewMapDatastore()), waiter, noopSender{}, &fakeViewer{view: &fakeStateView{}})
+
+	chinfo := ChannelInfo{UniqueAddr: paychAddr, AmountRedeemed: zeroAmt, ReservedAmount: zeroAmt}
+	require.NoError(t, pm.paymentChannels.Begin(paychAddr, &chinfo))
+
+	settleMh, err := multihash.Sum([]byte("failed-settle"), multihash.SHA2_256, -1)
+	require.NoError(t, err)
+	settleMcid := cid.NewCidV1(cid.Raw, settleMh)
+	require.Error(t, pm.WaitForSettle(context.Background(), paychAddr, settleMcid))
+
+	got, err := pm.GetPaymentChannelInfo(paychAddr)
+	require.NoError(t, err)
+	assert.False(t, got.Settled)
+	assert.Equal(t, cid.Undef, got.SettleMsg, "SettleMsg should be cleared even though the message failed")
+
+	pm.waiter = &syncWaiter{mr: &vm.MessageReceipt{ExitCode: exitcode.ExitCode(1)}, done: make(chan struct{})}
+	collectMh, err := multihash.Sum([]byte("failed-collect"), multihash.SHA2_256, -1)
+	require.NoError(t, err)
+	collectMcid := cid.NewCidV1(cid.Raw, collectMh)
+	require.Error(t, pm.WaitForCollect(context.Background(), paychAddr, collectMcid))
+
+	got, err = pm.GetPaymentChannelInfo(paychAddr)
+	require.NoError(t, err)
+	assert.False(t, got.Collected)
+	assert.Equal(t, cid.Undef, got.CollectMsg, "CollectMsg should be cleared even though the message failed")
+}
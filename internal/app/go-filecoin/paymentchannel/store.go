@@ -0,0 +1,150 @@
+package paymentchannel
+
+import (
+	"sort"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	paychActor "github.com/filecoin-project/specs-actors/actors/builtin/paych"
+	"github.com/ipfs/go-cid"
+)
+
+// VoucherInfo is a record of a voucher and any proof of delivery that
+// accompanied it.
+type VoucherInfo struct {
+	Voucher *paychActor.SignedVoucher
+	Proof   []byte
+}
+
+// ChannelInfo is the datastore record of a payment channel's on- and
+// off-chain state, as tracked by the Manager.
+type ChannelInfo struct {
+	From       address.Address
+	To         address.Address
+	UniqueAddr address.Address
+
+	NextLane  uint64
+	NextNonce uint64
+
+	Vouchers []*VoucherInfo
+
+	// AmountRedeemed is the total amount already paid out of this channel
+	// via submitted vouchers.
+	AmountRedeemed abi.TokenAmount
+	// PendingAmount is the amount of an outstanding add-funds message that
+	// has not yet landed on chain.
+	PendingAmount abi.TokenAmount
+	// ReservedAmount is the amount of this channel's balance that is
+	// promised to outstanding vouchers and may not be reserved again until
+	// released.
+	ReservedAmount abi.TokenAmount
+
+	// SubmittedMsg is the cid of an outstanding UpdateChannelState message
+	// for this channel, or cid.Undef if none is in flight.
+	SubmittedMsg cid.Cid
+	// SubmittedAmount is the cumulative Amount of the voucher submitted via
+	// SubmittedMsg, recorded so WaitForSubmit can compute how much of
+	// ReservedAmount that submission actually redeemed once it lands.
+	SubmittedAmount abi.TokenAmount
+	// SettleMsg is the cid of an outstanding Settle message for this
+	// channel, or cid.Undef if none is in flight.
+	SettleMsg cid.Cid
+	// CollectMsg is the cid of an outstanding Collect message for this
+	// channel, or cid.Undef if none is in flight.
+	CollectMsg cid.Cid
+	// Settled is true once this channel's Settle message has landed on chain.
+	Settled bool
+	// Collected is true once this channel's Collect message has landed on chain.
+	Collected bool
+}
+
+// IsZero returns true if chinfo is the empty value, i.e. there is no
+// matching channel in the store.
+func (ci *ChannelInfo) IsZero() bool {
+	return ci.UniqueAddr == address.Undef
+}
+
+// HasVoucher returns true if this channel already has a record of voucher.
+func (ci *ChannelInfo) HasVoucher(voucher *paychActor.SignedVoucher) bool {
+	for _, vi := range ci.Vouchers {
+		if vi.Voucher.Lane == voucher.Lane && vi.Voucher.Nonce == voucher.Nonce {
+			return true
+		}
+	}
+	return false
+}
+
+// LargestVoucherAmount returns the largest Amount among all vouchers stored
+// for this channel, across all lanes.
+func (ci *ChannelInfo) LargestVoucherAmount() abi.TokenAmount {
+	largest := abi.NewTokenAmount(0)
+	for _, vi := range ci.Vouchers {
+		if vi.Voucher.Amount.GreaterThan(largest) {
+			largest = vi.Voucher.Amount
+		}
+	}
+	return largest
+}
+
+// LanesWithVouchers returns, in ascending order, every lane that has at
+// least one stored voucher. SubmitVoucher redeems a single lane (one Sv) per
+// UpdateChannelState message, so a caller holding vouchers on more than one
+// lane should call SubmitVoucher once per lane returned here, or sign a
+// voucher whose Merges field consolidates multiple lanes into one Sv.
+func (ci *ChannelInfo) LanesWithVouchers() []uint64 {
+	seen := make(map[uint64]bool)
+	var lanes []uint64
+	for _, vi := range ci.Vouchers {
+		if seen[vi.Voucher.Lane] {
+			continue
+		}
+		seen[vi.Voucher.Lane] = true
+		lanes = append(lanes, vi.Voucher.Lane)
+	}
+	sort.Slice(lanes, func(i, j int) bool { return lanes[i] < lanes[j] })
+	return lanes
+}
+
+// PendingMsgKind identifies what an in-flight message recorded in the
+// Manager's pendingMessages store will do once it lands on chain.
+type PendingMsgKind string
+
+const (
+	// PendingMsgCreate marks a pending InitActor.Exec message creating a
+	// new payment channel.
+	PendingMsgCreate PendingMsgKind = "create"
+	// PendingMsgAddFunds marks a pending message adding funds to an
+	// existing payment channel.
+	PendingMsgAddFunds PendingMsgKind = "addFunds"
+)
+
+// PendingMessage is a record of an in-flight create or add-funds message,
+// persisted before the message is observed on chain so that Manager.Start
+// can recover it after a restart.
+type PendingMessage struct {
+	Mcid cid.Cid
+	Kind PendingMsgKind
+	From address.Address
+	To   address.Address
+	Amt  abi.TokenAmount
+	// Reserve is the amount, if any, that should be reserved against the
+	// resulting channel once this message lands, for PendingMsgCreate
+	// messages sent with a non-zero reserve. It is unused for
+	// PendingMsgAddFunds.
+	Reserve abi.TokenAmount
+}
+
+// bestVoucherForLane returns the largest-Amount voucher stored for lane, or
+// nil if this channel has no voucher on that lane.
+func (ci *ChannelInfo) bestVoucherForLane(lane uint64) *paychActor.SignedVoucher {
+	var best *paychActor.SignedVoucher
+	for _, vi := range ci.Vouchers {
+		if vi.Voucher.Lane != lane {
+			continue
+		}
+		if best == nil || vi.Voucher.Amount.GreaterThan(best.Amount) {
+			best = vi.Voucher
+		}
+	}
+	return best
+}
@@ -0,0 +1,26 @@
+package paymentchannel
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/specs-actors/actors/abi"
+)
+
+// ManagerStateView is the chain state surface the Manager needs in order to
+// create, fund, and validate payment channels and their vouchers.
+type ManagerStateView interface {
+	// MinerControlAddresses returns miner's owner and worker addresses.
+	MinerControlAddresses(ctx context.Context, miner address.Address) (owner, worker address.Address, err error)
+	// PaychActorParties returns the From/To addresses of the payment
+	// channel actor at paychAddr.
+	PaychActorParties(ctx context.Context, paychAddr address.Address) (from, to address.Address, err error)
+	// AccountSignerAddress resolves accountAddr, which may be an ID
+	// address, to the key address that signs on its behalf.
+	AccountSignerAddress(ctx context.Context, accountAddr address.Address) (address.Address, error)
+	// ChainHeadHeight returns the epoch of the current chain head.
+	ChainHeadHeight(ctx context.Context) (abi.ChainEpoch, error)
+	// PaychActorBalance returns the current on-chain balance of the payment
+	// channel actor at paychAddr.
+	PaychActorBalance(ctx context.Context, paychAddr address.Address) (abi.TokenAmount, error)
+}